@@ -0,0 +1,63 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package plan9addr parses and formats the address mask field used in
+// Plan 9 network file trees (ipifc status and ctl files): a CIDR
+// prefix length written as "/<n>" (see
+// https://9p.io/magic/man2html/3/ip). It is shared by the net
+// package's Plan 9 interface code and by net/ipifc, which both need
+// to interpret the same on-the-wire mask form; it works on raw bytes
+// rather than net.IP/net.IPMask so that the net package, which cannot
+// import anything that imports net, can use it too.
+package plan9addr
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ParseMask parses a Plan 9 ipifc status or ctl address line's mask
+// field, a CIDR prefix length written as "/<n>", into a mask of
+// bits/8 bytes.
+func ParseMask(s string, bits int) ([]byte, error) {
+	if len(s) < 2 || s[0] != '/' {
+		return nil, errors.New("plan9addr: invalid mask: " + s)
+	}
+	ones, err := strconv.Atoi(s[1:])
+	if err != nil || ones < 0 || ones > bits {
+		return nil, errors.New("plan9addr: invalid mask: " + s)
+	}
+	return CIDRMask(ones, bits), nil
+}
+
+// CIDRMask returns a mask of bits total bits with the first ones bits
+// set, mirroring net.CIDRMask without importing net (which would
+// create an import cycle for net's own Plan 9 interface code).
+func CIDRMask(ones, bits int) []byte {
+	mask := make([]byte, bits/8)
+	for i := range mask {
+		switch {
+		case ones >= 8:
+			mask[i] = 0xff
+			ones -= 8
+		case ones > 0:
+			mask[i] = ^byte(0xff >> uint(ones))
+			ones = 0
+		}
+	}
+	return mask
+}
+
+// MaskString renders mask, a CIDR mask as returned by CIDRMask, as
+// the "/<n>" prefix-length form Plan 9 expects on an add command.
+func MaskString(mask []byte) string {
+	ones := 0
+	for _, by := range mask {
+		for by&0x80 != 0 {
+			ones++
+			by <<= 1
+		}
+	}
+	return "/" + strconv.Itoa(ones)
+}
@@ -6,6 +6,7 @@ package net
 
 import "bufio"
 import "errors"
+import "internal/plan9addr"
 import "os"
 import "path/filepath"
 import "strings"
@@ -61,6 +62,7 @@ func readInterface(id int) (*Interface, error) {
 	if len(statusData) < 4 {
 		return nil, errors.New("Invalid status file of interface: " + ifaceStatus.Name())
 	}
+	medium := statusData[0]
 	device := statusData[1]
 	mtuStr := statusData[3]
 
@@ -93,11 +95,72 @@ func readInterface(id int) (*Interface, error) {
 		return nil, err
 	}
 
-	iface.Flags = FlagUp | FlagBroadcast | FlagLoopback
+	switch medium {
+	case mediumLoopback:
+		iface.Flags |= FlagLoopback | FlagUp
+	case mediumPPP, mediumTunnel:
+		iface.Flags |= FlagPointToPoint
+	case mediumEther, mediumGbe:
+		iface.Flags |= FlagBroadcast
+	}
+
+	if medium != mediumLoopback {
+		up, err := deviceLinkIsUp(device)
+		if err != nil {
+			return nil, err
+		}
+		if up {
+			iface.Flags |= FlagUp
+		}
+	}
+
+	// Every bound ipifc joins the default link-local multicast groups
+	// (see defaultMulticastGroups), so MulticastAddrs always returns
+	// at least those; keep Flags consistent with that.
+	iface.Flags |= FlagMulticast
 
 	return iface, nil
 }
 
+// Plan 9 ipifc media, as reported in the first field of an ipifc
+// status file.
+const (
+	mediumEther    = "ether"
+	mediumGbe      = "gbe"
+	mediumLoopback = "loopback"
+	mediumPPP      = "ppp"
+	mediumTunnel   = "tunnel"
+)
+
+// deviceLinkIsUp reports whether device's physical link is present,
+// as recorded in the "Link:" line of its stats file (e.g.
+// /net/ether0/stats). Devices with no stats file, such as loopback,
+// are treated as always up.
+func deviceLinkIsUp(device string) (bool, error) {
+	f, err := os.Open(filepath.Join(device, "stats"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "Link:") {
+			continue
+		}
+		return strings.TrimSpace(strings.TrimPrefix(line, "Link:")) == "up", nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+	// No Link: line present; assume the device is up.
+	return true, nil
+}
+
 func getInterfaceCount() (int, error) {
 	ipifc, err := os.Open(filepath.Join(netdir, "ipifc"))
 	if err != nil {
@@ -143,40 +206,149 @@ func interfaceAddrTable(ifi *Interface) ([]Addr, error) {
 		ifaces = []Interface{*ifi}
 	}
 
-	addresses := make([]Addr, len(ifaces))
-	for idx, iface := range ifaces {
+	var addresses []Addr
+	for _, iface := range ifaces {
 		statusFile, err := os.Open(filepath.Join(netdir, "ipifc", iface.Name, "status"))
 		if err != nil {
 			return nil, err
 		}
-		scanner := bufio.NewScanner(statusFile)
-		scanner.Scan()
-		scanner.Scan()
-		err = scanner.Err()
+		addrs, err := readInterfaceAddrs(statusFile)
+		statusFile.Close()
 		if err != nil {
 			return nil, err
 		}
-		// This assumes only a single address for the interface
+		addresses = append(addresses, addrs...)
+	}
+
+	return addresses, nil
+}
+
+// readInterfaceAddrs reads every address bound to an ipifc from its
+// status file, a line per address, each indented with a tab and
+// carrying the address and its mask.
+func readInterfaceAddrs(statusFile *os.File) ([]Addr, error) {
+	scanner := bufio.NewScanner(statusFile)
+	scanner.Scan() // skip the device line
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var addrs []Addr
+	for scanner.Scan() {
 		ipline := scanner.Text()
-		if ipline[0:1] != "\t" {
+		if !strings.HasPrefix(ipline, "\t") {
+			break
+		}
+		fields := strings.Fields(ipline)
+		if len(fields) < 2 {
 			return nil, errors.New("Cannot parse IP address for interface")
 		}
-		ipaddr := strings.Split(strings.Split(ipline, "\t")[1], " ")[0]
 
-		ip := ParseIP(ipaddr)
-
-		addr := IPAddr{IP: ip, Zone: ""}
-		if addr.IP == nil {
+		ip := ParseIP(fields[0])
+		if ip == nil {
 			return nil, errors.New("Unable to parse IP address for interface")
 		}
-		addresses[idx] = &addr
+		mask, err := parseIPMask(fields[1], ip)
+		if err != nil {
+			return nil, err
+		}
+
+		addrs = append(addrs, &IPNet{IP: ip, Mask: mask})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
 	}
 
-	return addresses, nil
+	return addrs, nil
+}
+
+// parseIPMask parses the mask field of an ipifc status address line,
+// a CIDR prefix length written as "/<n>" (see
+// https://9p.io/magic/man2html/3/ip).
+func parseIPMask(s string, ip IP) (IPMask, error) {
+	bits := 32
+	if ip.To4() == nil {
+		bits = 128
+	}
+	mask, err := plan9addr.ParseMask(s, bits)
+	if err != nil {
+		return nil, errors.New("Cannot parse IP mask for interface")
+	}
+	return IPMask(mask), nil
+}
+
+// defaultMulticastGroups are the link-local multicast groups that a
+// bound ipifc joins automatically, independent of anything recorded
+// in its ipmulti file.
+var defaultMulticastGroups = []string{
+	"224.0.0.1",
+	"ff02::1",
 }
 
 // interfaceMulticastAddrTable returns addresses for a specific
 // interface.
 func interfaceMulticastAddrTable(ifi *Interface) ([]Addr, error) {
-	return nil, nil
+	ifaces := []Interface{}
+	if ifi == nil {
+		var err error
+		ifaces, err = interfaceTable(0)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ifaces = []Interface{*ifi}
+	}
+
+	var addrs []Addr
+	for _, iface := range ifaces {
+		joined, err := readJoinedMulticastGroups(iface.Name)
+		if err != nil {
+			return nil, err
+		}
+		groups := append(append([]string{}, defaultMulticastGroups...), joined...)
+
+		seen := make(map[string]bool)
+		for _, g := range groups {
+			ip := ParseIP(g)
+			if ip == nil {
+				return nil, errors.New("Unable to parse multicast address for interface")
+			}
+			if seen[ip.String()] {
+				continue
+			}
+			seen[ip.String()] = true
+			addrs = append(addrs, &IPAddr{IP: ip})
+		}
+	}
+
+	return addrs, nil
+}
+
+// readJoinedMulticastGroups returns the multicast group addresses
+// that the ipifc named ifcName has explicitly joined, as recorded in
+// its ipmulti file. A missing ipmulti file just means no groups have
+// been joined beyond the defaults.
+func readJoinedMulticastGroups(ifcName string) ([]string, error) {
+	f, err := os.Open(filepath.Join(netdir, "ipifc", ifcName, "ipmulti"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var groups []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		groups = append(groups, strings.Fields(line)[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return groups, nil
 }
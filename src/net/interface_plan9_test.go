@@ -0,0 +1,162 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile writes data to the named file, creating its parent
+// directory if necessary.
+func writeFile(t *testing.T, name, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newFakeNetdir builds a fake /net tree rooted at a temporary
+// directory and points the package-level netdir at it, restoring the
+// previous value when the test finishes.
+func newFakeNetdir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	prev := netdir
+	netdir = dir
+	t.Cleanup(func() { netdir = prev })
+	return dir
+}
+
+func TestReadInterfaceFlagsLoopback(t *testing.T) {
+	dir := newFakeNetdir(t)
+	device := filepath.Join(dir, "lo0")
+	writeFile(t, filepath.Join(dir, "ipifc", "0", "status"), "loopback "+device+" 0 16000\n")
+	writeFile(t, filepath.Join(device, "addr"), "000000000000")
+
+	iface, err := readInterface(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FlagUp | FlagLoopback | FlagMulticast
+	if iface.Flags != want {
+		t.Errorf("Flags = %v, want %v", iface.Flags, want)
+	}
+}
+
+func TestReadInterfaceFlagsEtherUp(t *testing.T) {
+	dir := newFakeNetdir(t)
+	device := filepath.Join(dir, "ether0")
+	writeFile(t, filepath.Join(dir, "ipifc", "0", "status"), "ether "+device+" 0 1514\n")
+	writeFile(t, filepath.Join(device, "addr"), "0a1b2c3d4e5f")
+	writeFile(t, filepath.Join(device, "stats"), "Link: up\n")
+
+	iface, err := readInterface(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FlagUp | FlagBroadcast | FlagMulticast
+	if iface.Flags != want {
+		t.Errorf("Flags = %v, want %v", iface.Flags, want)
+	}
+}
+
+func TestReadInterfaceFlagsEtherDown(t *testing.T) {
+	dir := newFakeNetdir(t)
+	device := filepath.Join(dir, "ether0")
+	writeFile(t, filepath.Join(dir, "ipifc", "0", "status"), "ether "+device+" 0 1514\n")
+	writeFile(t, filepath.Join(device, "addr"), "0a1b2c3d4e5f")
+	writeFile(t, filepath.Join(device, "stats"), "Link: down\n")
+
+	iface, err := readInterface(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FlagBroadcast | FlagMulticast
+	if iface.Flags != want {
+		t.Errorf("Flags = %v, want %v", iface.Flags, want)
+	}
+	if iface.Flags&FlagUp != 0 {
+		t.Errorf("Flags = %v, want FlagUp unset", iface.Flags)
+	}
+}
+
+func TestInterfaceAddrTable(t *testing.T) {
+	dir := newFakeNetdir(t)
+	device := filepath.Join(dir, "ether0")
+	writeFile(t, filepath.Join(dir, "ipifc", "0", "status"),
+		"ether "+device+" 0 1514\n"+
+			"\t192.168.1.5 /24\n"+
+			"\tfe80::1 /64\n")
+	writeFile(t, filepath.Join(device, "addr"), "0a1b2c3d4e5f")
+
+	addrs, err := interfaceAddrTable(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 2 {
+		t.Fatalf("len(addrs) = %d, want 2", len(addrs))
+	}
+
+	v4, ok := addrs[0].(*IPNet)
+	if !ok || !v4.IP.Equal(ParseIP("192.168.1.5")) {
+		t.Errorf("addrs[0] = %v, want 192.168.1.5/24", addrs[0])
+	} else if ones, _ := v4.Mask.Size(); ones != 24 {
+		t.Errorf("addrs[0] mask = %v, want /24", v4.Mask)
+	}
+
+	v6, ok := addrs[1].(*IPNet)
+	if !ok || !v6.IP.Equal(ParseIP("fe80::1")) {
+		t.Errorf("addrs[1] = %v, want fe80::1/64", addrs[1])
+	} else if ones, _ := v6.Mask.Size(); ones != 64 {
+		t.Errorf("addrs[1] mask = %v, want /64", v6.Mask)
+	}
+}
+
+func TestInterfaceMulticastAddrTable(t *testing.T) {
+	dir := newFakeNetdir(t)
+	device := filepath.Join(dir, "ether0")
+	writeFile(t, filepath.Join(dir, "ipifc", "0", "status"),
+		"ether "+device+" 0 1514\n"+
+			"\t192.168.1.5 /24\n")
+	writeFile(t, filepath.Join(device, "addr"), "0a1b2c3d4e5f")
+	writeFile(t, filepath.Join(dir, "ipifc", "0", "ipmulti"), "224.0.0.251\nff02::1:ff00:0\n")
+
+	addrs, err := interfaceMulticastAddrTable(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"224.0.0.1", "ff02::1", "224.0.0.251", "ff02::1:ff00:0"}
+	if len(addrs) != len(want) {
+		t.Fatalf("len(addrs) = %d, want %d: %v", len(addrs), len(want), addrs)
+	}
+	for i, w := range want {
+		a, ok := addrs[i].(*IPAddr)
+		if !ok || !a.IP.Equal(ParseIP(w)) {
+			t.Errorf("addrs[%d] = %v, want %s", i, addrs[i], w)
+		}
+	}
+}
+
+func TestReadInterfaceFlagsPPP(t *testing.T) {
+	dir := newFakeNetdir(t)
+	device := filepath.Join(dir, "ppp0")
+	writeFile(t, filepath.Join(dir, "ipifc", "0", "status"), "ppp "+device+" 0 1500\n")
+	writeFile(t, filepath.Join(device, "addr"), "000000000000")
+
+	iface, err := readInterface(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FlagUp | FlagPointToPoint | FlagMulticast
+	if iface.Flags != want {
+		t.Errorf("Flags = %v, want %v", iface.Flags, want)
+	}
+}
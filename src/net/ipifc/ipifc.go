@@ -0,0 +1,247 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ipifc models the declarative configuration of a Plan 9 IP
+// interface (ipifc). A Config can be parsed from an ipifc's status
+// file and the history of commands written to its ctl file, checked
+// for consistency with Validate, and turned back into the bind, mtu,
+// add and dhcp control messages that realize it on a newly cloned
+// ipifc under /net/ipifc; no unbind message is ever emitted.
+//
+// This gives Plan 9 programs the same "declare a config, then apply
+// it" workflow that ifupdown-style tools provide on other systems,
+// rather than issuing ctl writes by hand.
+package ipifc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"internal/plan9addr"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Addr is an address bound to an ipifc, together with its mask.
+type Addr struct {
+	IP   net.IP
+	Mask net.IPMask
+}
+
+// Config is the declarative description of a Plan 9 IP interface: the
+// medium and device it binds, its MTU, the addresses added to it, an
+// optional default gateway, and whether it is configured by DHCP
+// instead of static addresses.
+type Config struct {
+	Medium  string
+	Device  string
+	MTU     int
+	Addrs   []Addr
+	Gateway net.IP
+	DHCP    bool
+}
+
+// Parse reads an ipifc's status file together with ctlHistory, the
+// newline-separated sequence of commands previously written to the
+// ipifc's ctl file, and builds the Config they describe.
+func Parse(status, ctlHistory string) (*Config, error) {
+	lines := strings.SplitN(status, "\n", 2)
+	fields := strings.Fields(lines[0])
+	if len(fields) < 4 {
+		return nil, errors.New("ipifc: invalid status line: " + lines[0])
+	}
+
+	c := &Config{
+		Medium: fields[0],
+		Device: fields[1],
+	}
+	mtu, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return nil, errors.New("ipifc: invalid mtu in status line: " + lines[0])
+	}
+	c.MTU = mtu
+
+	if len(lines) > 1 {
+		scanner := bufio.NewScanner(strings.NewReader(lines[1]))
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "\t") {
+				continue
+			}
+			addrFields := strings.Fields(line)
+			if len(addrFields) < 2 {
+				return nil, errors.New("ipifc: invalid address line: " + line)
+			}
+			ip := net.ParseIP(addrFields[0])
+			if ip == nil {
+				return nil, errors.New("ipifc: invalid address: " + addrFields[0])
+			}
+			mask, err := parseMask(addrFields[1], ip)
+			if err != nil {
+				return nil, err
+			}
+			c.Addrs = append(c.Addrs, Addr{IP: ip, Mask: mask})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, line := range strings.Split(ctlHistory, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "dhcp":
+			c.DHCP = true
+		case "add":
+			if len(fields) == 4 {
+				// A fourth field on an add line is the interface's
+				// default gateway, as accepted by Plan 9's ipconfig
+				// and emitted by Commands.
+				if gw := net.ParseIP(fields[3]); gw != nil {
+					c.Gateway = gw
+				}
+			}
+		case "unbind":
+			// Addrs already reflects the ipifc's current bound
+			// addresses from status, which is authoritative; only
+			// reset the fields that history alone carries, so a
+			// stale unbind before a later rebind can't erase
+			// addresses the status file shows are still present.
+			c.Gateway = nil
+			c.DHCP = false
+		}
+	}
+
+	return c, nil
+}
+
+// parseMask parses the mask field of an ipifc status or ctl address
+// line, a CIDR prefix length written as "/<n>", delegating to
+// plan9addr, which is shared with the net package's Plan 9 interface
+// code.
+func parseMask(s string, ip net.IP) (net.IPMask, error) {
+	bits := 32
+	if ip.To4() == nil {
+		bits = 128
+	}
+	mask, err := plan9addr.ParseMask(s, bits)
+	if err != nil {
+		return nil, errors.New("ipifc: invalid mask: " + s)
+	}
+	return net.IPMask(mask), nil
+}
+
+// maskString renders mask as the "/<n>" prefix-length form Plan 9
+// expects on an add command.
+func maskString(mask net.IPMask) string {
+	return plan9addr.MaskString([]byte(mask))
+}
+
+// Validate reports whether c is a configuration that can be realized
+// against a Plan 9 IP stack.
+func (c *Config) Validate() error {
+	if c.Device == "" {
+		return errors.New("ipifc: missing device")
+	}
+	if c.Medium == "" {
+		return errors.New("ipifc: missing medium")
+	}
+	if c.DHCP && len(c.Addrs) > 0 {
+		return errors.New("ipifc: DHCP and static addresses are mutually exclusive")
+	}
+
+	for _, a := range c.Addrs {
+		if a.IP == nil || a.Mask == nil {
+			return errors.New("ipifc: address missing an IP or a mask")
+		}
+		is4 := a.IP.To4() != nil
+		if is4 != (len(a.Mask) == net.IPv4len) {
+			return errors.New("ipifc: address and mask version mismatch: " + a.IP.String())
+		}
+	}
+
+	if c.Gateway != nil && !c.DHCP {
+		reachable := false
+		for _, a := range c.Addrs {
+			if a.IP.Mask(a.Mask).Equal(c.Gateway.Mask(a.Mask)) {
+				reachable = true
+				break
+			}
+		}
+		if !reachable {
+			return errors.New("ipifc: gateway " + c.Gateway.String() + " is not reachable within any bound subnet")
+		}
+	}
+
+	return nil
+}
+
+// Commands returns the sequence of control messages that, written in
+// order to a cloned ipifc's ctl file, realize c.
+func (c *Config) Commands() []string {
+	cmds := []string{fmt.Sprintf("bind %s %s", c.Medium, c.Device)}
+	if c.MTU > 0 {
+		cmds = append(cmds, fmt.Sprintf("mtu %d", c.MTU))
+	}
+
+	if c.DHCP {
+		cmds = append(cmds, "dhcp")
+		return cmds
+	}
+
+	for _, a := range c.Addrs {
+		if c.Gateway != nil && a.IP.Mask(a.Mask).Equal(c.Gateway.Mask(a.Mask)) {
+			cmds = append(cmds, fmt.Sprintf("add %s %s %s", a.IP, maskString(a.Mask), c.Gateway))
+		} else {
+			cmds = append(cmds, fmt.Sprintf("add %s %s", a.IP, maskString(a.Mask)))
+		}
+	}
+
+	return cmds
+}
+
+// Apply validates c and realizes it by cloning a new ipifc under
+// netdir (typically "/net") and issuing its Commands against the
+// clone's ctl file. It returns the name of the ipifc that was
+// configured.
+func (c *Config) Apply(netdir string) (string, error) {
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+
+	clone, err := os.Open(filepath.Join(netdir, "ipifc", "clone"))
+	if err != nil {
+		return "", err
+	}
+	defer clone.Close()
+
+	scanner := bufio.NewScanner(clone)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", errors.New("ipifc: clone did not return an ifc number")
+	}
+	name := strings.TrimSpace(scanner.Text())
+
+	ctl, err := os.OpenFile(filepath.Join(netdir, "ipifc", name, "ctl"), os.O_WRONLY, 0)
+	if err != nil {
+		return "", err
+	}
+	defer ctl.Close()
+
+	for _, cmd := range c.Commands() {
+		if _, err := ctl.WriteString(cmd + "\n"); err != nil {
+			return "", err
+		}
+	}
+
+	return name, nil
+}
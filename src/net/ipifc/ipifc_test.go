@@ -0,0 +1,124 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ipifc
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParseRoundTripsAddrsAndGateway(t *testing.T) {
+	status := "ether /net/ether0 0 1514\n" +
+		"\t192.168.1.5 /24\n" +
+		"\tfe80::1 /64\n"
+	ctlHistory := "bind ether /net/ether0\n" +
+		"add 192.168.1.5 /24 192.168.1.1\n"
+
+	c, err := Parse(status, ctlHistory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Addrs) != 2 {
+		t.Fatalf("len(Addrs) = %d, want 2", len(c.Addrs))
+	}
+	if !c.Addrs[0].IP.Equal(net.ParseIP("192.168.1.5")) {
+		t.Errorf("Addrs[0].IP = %v, want 192.168.1.5", c.Addrs[0].IP)
+	}
+	if ones, _ := c.Addrs[0].Mask.Size(); ones != 24 {
+		t.Errorf("Addrs[0].Mask = %v, want /24", c.Addrs[0].Mask)
+	}
+	if !c.Addrs[1].IP.Equal(net.ParseIP("fe80::1")) {
+		t.Errorf("Addrs[1].IP = %v, want fe80::1", c.Addrs[1].IP)
+	}
+	if ones, _ := c.Addrs[1].Mask.Size(); ones != 64 {
+		t.Errorf("Addrs[1].Mask = %v, want /64", c.Addrs[1].Mask)
+	}
+	if !c.Gateway.Equal(net.ParseIP("192.168.1.1")) {
+		t.Errorf("Gateway = %v, want 192.168.1.1", c.Gateway)
+	}
+
+	// Commands should emit a ctl form that Parse can read back.
+	cmds := c.Commands()
+	reparsed, err := Parse(status, joinLines(cmds))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reparsed.Gateway.Equal(c.Gateway) {
+		t.Errorf("round-tripped Gateway = %v, want %v", reparsed.Gateway, c.Gateway)
+	}
+}
+
+func TestParseStaleUnbindKeepsCurrentAddrs(t *testing.T) {
+	status := "ether /net/ether0 0 1514\n" +
+		"\t192.168.1.10 /24\n"
+	ctlHistory := "bind ether /net/ether0\n" +
+		"add 10.0.0.5 /24\n" +
+		"unbind\n" +
+		"bind ether /net/ether0\n" +
+		"add 192.168.1.10 /24\n"
+
+	c, err := Parse(status, ctlHistory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Addrs) != 1 || !c.Addrs[0].IP.Equal(net.ParseIP("192.168.1.10")) {
+		t.Errorf("Addrs = %v, want just 192.168.1.10 from status", c.Addrs)
+	}
+}
+
+func TestValidateRejectsDHCPWithStaticAddrs(t *testing.T) {
+	c := &Config{
+		Medium: "ether",
+		Device: "/net/ether0",
+		DHCP:   true,
+		Addrs:  []Addr{{IP: net.ParseIP("192.168.1.5"), Mask: net.CIDRMask(24, 32)}},
+	}
+	if err := c.Validate(); err == nil {
+		t.Error("Validate succeeded for DHCP with static addresses, want error")
+	}
+}
+
+func TestValidateRejectsAddrMaskVersionMismatch(t *testing.T) {
+	c := &Config{
+		Medium: "ether",
+		Device: "/net/ether0",
+		Addrs:  []Addr{{IP: net.ParseIP("192.168.1.5"), Mask: net.CIDRMask(64, 128)}},
+	}
+	if err := c.Validate(); err == nil {
+		t.Error("Validate succeeded for an IPv4 address with an IPv6 mask, want error")
+	}
+}
+
+func TestValidateRejectsUnreachableGateway(t *testing.T) {
+	c := &Config{
+		Medium:  "ether",
+		Device:  "/net/ether0",
+		Addrs:   []Addr{{IP: net.ParseIP("192.168.1.5"), Mask: net.CIDRMask(24, 32)}},
+		Gateway: net.ParseIP("10.0.0.1"),
+	}
+	if err := c.Validate(); err == nil {
+		t.Error("Validate succeeded for a gateway outside any bound subnet, want error")
+	}
+}
+
+func TestValidateAcceptsReachableGateway(t *testing.T) {
+	c := &Config{
+		Medium:  "ether",
+		Device:  "/net/ether0",
+		Addrs:   []Addr{{IP: net.ParseIP("192.168.1.5"), Mask: net.CIDRMask(24, 32)}},
+		Gateway: net.ParseIP("192.168.1.1"),
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate failed for a gateway within a bound subnet: %v", err)
+	}
+}
+
+func joinLines(lines []string) string {
+	s := ""
+	for _, l := range lines {
+		s += l + "\n"
+	}
+	return s
+}